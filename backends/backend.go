@@ -0,0 +1,144 @@
+// Package backends defines the pluggable provider interface used by the
+// bot and the registry that backend implementations register themselves
+// into on init(). Adding a new provider (Anthropic-direct, Ollama, a local
+// llama.cpp gRPC server, ...) means adding one file to this package and
+// calling Register/RegisterModel from its init() — main.go never changes.
+package backends
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrStreamingUnsupported is returned by Stream on backends that can only
+// produce a response in one shot.
+var ErrStreamingUnsupported = errors.New("backends: streaming not supported by this backend")
+
+// ErrImageGenerationUnsupported is returned by GenerateImage on backends
+// that have no image-generation capability.
+var ErrImageGenerationUnsupported = errors.New("backends: image generation not supported by this backend")
+
+// Image is an inline attachment on a Message, for multimodal providers.
+type Image struct {
+	MimeType string
+	Data     []byte
+}
+
+// Message is one turn of a conversation handed to a backend. It is
+// intentionally minimal; richer history (tokens, timestamps) lives above
+// this package and gets flattened into Messages per request.
+type Message struct {
+	Role    string
+	Content string
+	Images  []Image
+}
+
+// ChatOptions carries the per-request knobs a backend needs beyond the
+// conversation itself.
+type ChatOptions struct {
+	// Model is the provider-specific model identifier, e.g.
+	// "openai/gpt-4o" or "gemini-2.0-flash".
+	Model string
+
+	// Tools, when non-empty, are advertised to the model as callable
+	// functions. Backends that don't support tool-calling ignore this.
+	Tools []ToolSchema
+
+	// InvokeTool dispatches a model-issued tool call to its
+	// implementation; required when Tools is non-empty.
+	InvokeTool func(ctx context.Context, name string, args json.RawMessage) (string, error)
+
+	// MaxToolIterations bounds how many tool-call round trips a single
+	// Chat call will make before giving up with an error. Zero means the
+	// backend's own default.
+	MaxToolIterations int
+}
+
+// ToolSchema describes one callable tool for a provider's function/tool
+// calling API. Parameters is a JSON Schema object with any local $refs
+// already resolved, since not every provider dereferences them.
+type ToolSchema struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// Delta is one incremental piece of a streamed response. Err is set (with
+// Content empty) when the stream fails partway through; the channel is
+// closed immediately after.
+type Delta struct {
+	Content string
+	Err     error
+}
+
+// Backend is implemented by every provider the bot can route a chat to.
+type Backend interface {
+	Name() string
+	Chat(ctx context.Context, messages []Message, opts ChatOptions) (string, error)
+	Stream(ctx context.Context, messages []Message, opts ChatOptions) (<-chan Delta, error)
+	SupportsImages() bool
+
+	// GenerateImage synthesizes an image from a text prompt and returns
+	// its raw bytes and MIME type. Backends without image-generation
+	// support return ErrImageGenerationUnsupported.
+	GenerateImage(ctx context.Context, prompt string, opts ChatOptions) ([]byte, string, error)
+}
+
+// ModelEntry maps a short, user-facing model key (what /model shows and
+// userModels stores) to the backend that serves it and the model id to
+// pass in ChatOptions.
+type ModelEntry struct {
+	Backend string
+	Model   string
+
+	// ContextTokens is an approximate context-window budget for this
+	// model, used to decide when history needs trimming or
+	// summarizing. Zero means "use the caller's default".
+	ContextTokens int
+
+	// SupportsVision gates whether this specific model accepts image
+	// attachments on a Message; callers should reject photo/document
+	// input with a helpful message when it's false.
+	SupportsVision bool
+}
+
+var (
+	registry = map[string]Backend{}
+	models   = map[string]ModelEntry{}
+)
+
+// Register makes a backend available under its own Name(). Call from the
+// backend's init().
+func Register(b Backend) {
+	registry[b.Name()] = b
+}
+
+// RegisterModel exposes a short model key to callers of Lookup/ModelKeys.
+// Call from the owning backend's init(), alongside Register.
+func RegisterModel(key string, entry ModelEntry) {
+	models[key] = entry
+}
+
+// Get returns the backend registered under name, if any.
+func Get(name string) (Backend, bool) {
+	b, ok := registry[name]
+	return b, ok
+}
+
+// Lookup resolves a short model key (e.g. "gpt-4") to the backend and
+// model id that serve it.
+func Lookup(key string) (ModelEntry, bool) {
+	e, ok := models[key]
+	return e, ok
+}
+
+// ModelKeys returns every registered short model key, in no particular
+// order, for building the /model selection keyboard.
+func ModelKeys() []string {
+	keys := make([]string, 0, len(models))
+	for k := range models {
+		keys = append(keys, k)
+	}
+	return keys
+}
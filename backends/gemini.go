@@ -0,0 +1,219 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+func init() {
+	Register(&Gemini{})
+	RegisterModel("gemini", ModelEntry{Backend: "gemini", Model: "gemini-2.0-flash", ContextTokens: 1000000, SupportsVision: true})
+}
+
+// Gemini talks to the Google Generative Language API.
+type Gemini struct{}
+
+func (*Gemini) Name() string         { return "gemini" }
+func (*Gemini) SupportsImages() bool { return true }
+func (*Gemini) apiKey() string       { return os.Getenv("GEMINI_API_KEY") }
+
+type geminiInlineData struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"`
+}
+
+type geminiPart struct {
+	Text       string            `json:"text,omitempty"`
+	InlineData *geminiInlineData `json:"inline_data,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	Contents []geminiContent `json:"contents"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+// toGeminiRequest builds Gemini's native per-turn contents array, mapping
+// the bot's "assistant" role to Gemini's "model" so a multi-turn history
+// stays legible to it instead of collapsing into one undifferentiated
+// block of text. Each message's own images travel as inline_data parts
+// on its own turn, alongside its text.
+func toGeminiRequest(messages []Message) geminiRequest {
+	contents := make([]geminiContent, 0, len(messages))
+	for _, m := range messages {
+		role := "user"
+		if m.Role == "assistant" {
+			role = "model"
+		}
+		var parts []geminiPart
+		if m.Content != "" {
+			parts = append(parts, geminiPart{Text: m.Content})
+		}
+		for _, img := range m.Images {
+			parts = append(parts, geminiPart{
+				InlineData: &geminiInlineData{
+					MimeType: img.MimeType,
+					Data:     base64.StdEncoding.EncodeToString(img.Data),
+				},
+			})
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+	return geminiRequest{Contents: contents}
+}
+
+func (g *Gemini) Chat(ctx context.Context, messages []Message, opts ChatOptions) (string, error) {
+	apiKey := g.apiKey()
+	if apiKey == "" {
+		return "", fmt.Errorf("отсутствует GEMINI_API_KEY")
+	}
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", opts.Model, apiKey)
+	b, _ := json.Marshal(toGeminiRequest(messages))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(b))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("ошибка API Gemini: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ошибка API Gemini (%d)", resp.StatusCode)
+	}
+	var gr geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gr); err != nil {
+		return "", fmt.Errorf("ошибка разбора ответа Gemini: %w", err)
+	}
+	if len(gr.Candidates) == 0 || len(gr.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("пустой ответ от Gemini")
+	}
+	return gr.Candidates[0].Content.Parts[0].Text, nil
+}
+
+// geminiImageRequest asks generateContent for image output alongside text,
+// via the same responseModalities knob Gemini uses for its image-capable
+// models (e.g. gemini-2.0-flash-exp-image-generation).
+type geminiImageRequest struct {
+	Contents         []geminiContent `json:"contents"`
+	GenerationConfig struct {
+		ResponseModalities []string `json:"responseModalities"`
+	} `json:"generationConfig"`
+}
+
+func (g *Gemini) GenerateImage(ctx context.Context, prompt string, opts ChatOptions) ([]byte, string, error) {
+	apiKey := g.apiKey()
+	if apiKey == "" {
+		return nil, "", fmt.Errorf("отсутствует GEMINI_API_KEY")
+	}
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", opts.Model, apiKey)
+	reqBody := geminiImageRequest{
+		Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: prompt}}}},
+	}
+	reqBody.GenerationConfig.ResponseModalities = []string{"TEXT", "IMAGE"}
+	b, _ := json.Marshal(reqBody)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка API Gemini: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("ошибка API Gemini (%d)", resp.StatusCode)
+	}
+	var gr geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gr); err != nil {
+		return nil, "", fmt.Errorf("ошибка разбора ответа Gemini: %w", err)
+	}
+	if len(gr.Candidates) == 0 {
+		return nil, "", fmt.Errorf("пустой ответ от Gemini")
+	}
+	for _, part := range gr.Candidates[0].Content.Parts {
+		if part.InlineData == nil {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка декодирования изображения: %w", err)
+		}
+		return data, part.InlineData.MimeType, nil
+	}
+	return nil, "", fmt.Errorf("Gemini не вернул изображение")
+}
+
+func (g *Gemini) Stream(ctx context.Context, messages []Message, opts ChatOptions) (<-chan Delta, error) {
+	apiKey := g.apiKey()
+	if apiKey == "" {
+		return nil, fmt.Errorf("отсутствует GEMINI_API_KEY")
+	}
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", opts.Model, apiKey)
+	b, _ := json.Marshal(toGeminiRequest(messages))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка API Gemini: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("ошибка API Gemini (%d)", resp.StatusCode)
+	}
+
+	out := make(chan Delta)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		err := readSSE(resp.Body, func(data string) error {
+			var chunk geminiResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return nil
+			}
+			if len(chunk.Candidates) == 0 {
+				return nil
+			}
+			for _, part := range chunk.Candidates[0].Content.Parts {
+				if part.Text != "" {
+					out <- Delta{Content: part.Text}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			out <- Delta{Err: err}
+		}
+	}()
+	return out, nil
+}
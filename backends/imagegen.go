@@ -0,0 +1,27 @@
+package backends
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// decodeDataURL parses a "data:<mime>;base64,<payload>" string, the shape
+// providers return inline image output in, into raw bytes and a MIME type.
+func decodeDataURL(url string) ([]byte, string, error) {
+	const prefix = "data:"
+	if !strings.HasPrefix(url, prefix) {
+		return nil, "", fmt.Errorf("не data URL: %q", url)
+	}
+	rest := strings.TrimPrefix(url, prefix)
+	meta, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, "", fmt.Errorf("некорректный data URL")
+	}
+	mimeType, _, _ := strings.Cut(meta, ";base64")
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка декодирования изображения: %w", err)
+	}
+	return data, mimeType, nil
+}
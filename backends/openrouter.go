@@ -0,0 +1,397 @@
+package backends
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+func init() {
+	Register(&OpenRouter{})
+	RegisterModel("gpt-3.5", ModelEntry{Backend: "openrouter", Model: "openai/gpt-3.5-turbo", ContextTokens: 16000})
+	RegisterModel("gpt-4", ModelEntry{Backend: "openrouter", Model: "openai/gpt-4o", ContextTokens: 128000, SupportsVision: true})
+	RegisterModel("deepseek", ModelEntry{Backend: "openrouter", Model: "deepseek/deepseek-r1", ContextTokens: 64000})
+	RegisterModel("qwen", ModelEntry{Backend: "openrouter", Model: "qwen/qwen-plus", ContextTokens: 32000})
+	RegisterModel("claude", ModelEntry{Backend: "openrouter", Model: "anthropic/claude-3.5-haiku", ContextTokens: 200000})
+	RegisterModel("image", ModelEntry{Backend: "openrouter", Model: "google/gemini-2.5-flash-image-preview"})
+}
+
+// OpenRouter talks to https://openrouter.ai/api/v1/chat/completions, which
+// proxies a large number of third-party models behind one OpenAI-shaped API.
+type OpenRouter struct{}
+
+func (*OpenRouter) Name() string         { return "openrouter" }
+func (*OpenRouter) SupportsImages() bool { return true }
+func (*OpenRouter) apiKey() string       { return os.Getenv("OPENROUTER_API_KEY") }
+
+// maxToolIterations is the default bound on tool-call round trips within
+// a single Chat call, used when ChatOptions.MaxToolIterations is zero.
+const maxToolIterations = 5
+
+type orToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type orMessage struct {
+	Role       string       `json:"role"`
+	Content    any          `json:"content,omitempty"`
+	ToolCalls  []orToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string       `json:"tool_call_id,omitempty"`
+	Name       string       `json:"name,omitempty"`
+}
+
+// orContentPart is one element of a multimodal message's content array,
+// the shape OpenRouter (mirroring OpenAI) expects when a message carries
+// image attachments alongside text.
+type orContentPart struct {
+	Type     string      `json:"type"`
+	Text     string      `json:"text,omitempty"`
+	ImageURL *orImageURL `json:"image_url,omitempty"`
+}
+
+type orImageURL struct {
+	URL string `json:"url"`
+}
+
+type orToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type orTool struct {
+	Type     string         `json:"type"`
+	Function orToolFunction `json:"function"`
+}
+
+type orRequest struct {
+	Model    string      `json:"model"`
+	Messages []orMessage `json:"messages"`
+	Stream   bool        `json:"stream,omitempty"`
+	Tools    []orTool    `json:"tools,omitempty"`
+}
+
+type orResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string       `json:"content"`
+			ToolCalls []orToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+func toORTools(schemas []ToolSchema) []orTool {
+	if len(schemas) == 0 {
+		return nil
+	}
+	out := make([]orTool, len(schemas))
+	for i, s := range schemas {
+		out[i] = orTool{
+			Type: "function",
+			Function: orToolFunction{
+				Name:        s.Name,
+				Description: s.Description,
+				Parameters:  s.Parameters,
+			},
+		}
+	}
+	return out
+}
+
+type orToolCallDelta struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type orStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string            `json:"content"`
+			ToolCalls []orToolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// mergeToolCallDelta folds one streamed tool-call fragment into the
+// accumulated list, keyed by its index — OpenRouter (mirroring OpenAI)
+// sends a call's id/name in its first fragment and splits the argument
+// text across the ones that follow.
+func mergeToolCallDelta(calls []orToolCall, delta orToolCallDelta) []orToolCall {
+	for len(calls) <= delta.Index {
+		calls = append(calls, orToolCall{})
+	}
+	call := &calls[delta.Index]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		call.Function.Name = delta.Function.Name
+	}
+	call.Function.Arguments += delta.Function.Arguments
+	return calls
+}
+
+func toORMessages(messages []Message) []orMessage {
+	out := make([]orMessage, len(messages))
+	for i, m := range messages {
+		if len(m.Images) == 0 {
+			out[i] = orMessage{Role: m.Role, Content: m.Content}
+			continue
+		}
+		parts := make([]orContentPart, 0, len(m.Images)+1)
+		if m.Content != "" {
+			parts = append(parts, orContentPart{Type: "text", Text: m.Content})
+		}
+		for _, img := range m.Images {
+			url := fmt.Sprintf("data:%s;base64,%s", img.MimeType, base64.StdEncoding.EncodeToString(img.Data))
+			parts = append(parts, orContentPart{Type: "image_url", ImageURL: &orImageURL{URL: url}})
+		}
+		out[i] = orMessage{Role: m.Role, Content: parts}
+	}
+	return out
+}
+
+func (o *OpenRouter) Chat(ctx context.Context, messages []Message, opts ChatOptions) (string, error) {
+	apiKey := o.apiKey()
+	if apiKey == "" {
+		return "", fmt.Errorf("отсутствует OPENROUTER_API_KEY")
+	}
+
+	maxIterations := opts.MaxToolIterations
+	if maxIterations <= 0 {
+		maxIterations = maxToolIterations
+	}
+	conv := toORMessages(messages)
+	tools := toORTools(opts.Tools)
+
+	for i := 0; i < maxIterations; i++ {
+		reqBody := orRequest{Model: opts.Model, Messages: conv, Tools: tools}
+		b, _ := json.Marshal(reqBody)
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(b))
+		if err != nil {
+			return "", err
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return "", fmt.Errorf("ошибка соединения с OpenRouter: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			buf := new(bytes.Buffer)
+			_, _ = buf.ReadFrom(resp.Body)
+			resp.Body.Close()
+			text := buf.String()
+			if len(text) > 200 {
+				text = text[:200]
+			}
+			return "", fmt.Errorf("ошибка OpenRouter (%d): %s", resp.StatusCode, text)
+		}
+		var orResp orResponse
+		err = json.NewDecoder(resp.Body).Decode(&orResp)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("некорректный ответ от OpenRouter: %w", err)
+		}
+		if len(orResp.Choices) == 0 {
+			return "", fmt.Errorf("некорректный ответ от OpenRouter: пустые choices")
+		}
+
+		msg := orResp.Choices[0].Message
+		if len(msg.ToolCalls) == 0 {
+			return msg.Content, nil
+		}
+		if opts.InvokeTool == nil {
+			return msg.Content, nil
+		}
+
+		conv = append(conv, orMessage{Role: "assistant", Content: msg.Content, ToolCalls: msg.ToolCalls})
+		for _, call := range msg.ToolCalls {
+			result, err := opts.InvokeTool(ctx, call.Function.Name, json.RawMessage(call.Function.Arguments))
+			if err != nil {
+				result = fmt.Sprintf("ошибка: %v", err)
+			}
+			conv = append(conv, orMessage{Role: "tool", Content: result, ToolCallID: call.ID, Name: call.Function.Name})
+		}
+	}
+	return "", fmt.Errorf("превышено максимальное число вызовов инструментов (%d)", maxIterations)
+}
+
+// orImageRequest mirrors orRequest but asks for image output alongside
+// text, per OpenRouter's multimodal-output models (e.g. the "image" model
+// key registered above).
+type orImageRequest struct {
+	Model      string      `json:"model"`
+	Messages   []orMessage `json:"messages"`
+	Modalities []string    `json:"modalities"`
+}
+
+type orImageResponse struct {
+	Choices []struct {
+		Message struct {
+			Images []struct {
+				ImageURL orImageURL `json:"image_url"`
+			} `json:"images"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+func (o *OpenRouter) GenerateImage(ctx context.Context, prompt string, opts ChatOptions) ([]byte, string, error) {
+	apiKey := o.apiKey()
+	if apiKey == "" {
+		return nil, "", fmt.Errorf("отсутствует OPENROUTER_API_KEY")
+	}
+	reqBody := orImageRequest{
+		Model:      opts.Model,
+		Messages:   []orMessage{{Role: "user", Content: prompt}},
+		Modalities: []string{"image", "text"},
+	}
+	b, _ := json.Marshal(reqBody)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, "", err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка соединения с OpenRouter: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		text := buf.String()
+		if len(text) > 200 {
+			text = text[:200]
+		}
+		return nil, "", fmt.Errorf("ошибка OpenRouter (%d): %s", resp.StatusCode, text)
+	}
+	var orResp orImageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&orResp); err != nil {
+		return nil, "", fmt.Errorf("некорректный ответ от OpenRouter: %w", err)
+	}
+	if len(orResp.Choices) == 0 || len(orResp.Choices[0].Message.Images) == 0 {
+		return nil, "", fmt.Errorf("OpenRouter не вернул изображение")
+	}
+	return decodeDataURL(orResp.Choices[0].Message.Images[0].ImageURL.URL)
+}
+
+// Stream mirrors Chat's tool-call loop but forwards content as it arrives:
+// each round trip streams deltas straight to out, and if the model asks
+// for tool calls instead of (or after) some text, they're run and another
+// round starts — same as Chat, just incremental.
+func (o *OpenRouter) Stream(ctx context.Context, messages []Message, opts ChatOptions) (<-chan Delta, error) {
+	if o.apiKey() == "" {
+		return nil, fmt.Errorf("отсутствует OPENROUTER_API_KEY")
+	}
+
+	maxIterations := opts.MaxToolIterations
+	if maxIterations <= 0 {
+		maxIterations = maxToolIterations
+	}
+	conv := toORMessages(messages)
+	toolDefs := toORTools(opts.Tools)
+
+	out := make(chan Delta)
+	go func() {
+		defer close(out)
+		for i := 0; i < maxIterations; i++ {
+			toolCalls, err := o.streamOnce(ctx, conv, toolDefs, opts.Model, out)
+			if err != nil {
+				out <- Delta{Err: err}
+				return
+			}
+			if len(toolCalls) == 0 || opts.InvokeTool == nil {
+				return
+			}
+
+			conv = append(conv, orMessage{Role: "assistant", ToolCalls: toolCalls})
+			for _, call := range toolCalls {
+				result, err := opts.InvokeTool(ctx, call.Function.Name, json.RawMessage(call.Function.Arguments))
+				if err != nil {
+					result = fmt.Sprintf("ошибка: %v", err)
+				}
+				conv = append(conv, orMessage{Role: "tool", Content: result, ToolCallID: call.ID, Name: call.Function.Name})
+			}
+		}
+		out <- Delta{Err: fmt.Errorf("превышено максимальное число вызовов инструментов (%d)", maxIterations)}
+	}()
+	return out, nil
+}
+
+// streamOnce issues a single streaming request, forwarding content deltas
+// to out as they arrive, and returns any tool calls the model asked for
+// once the response completes.
+func (o *OpenRouter) streamOnce(ctx context.Context, conv []orMessage, toolDefs []orTool, model string, out chan<- Delta) ([]orToolCall, error) {
+	reqBody := orRequest{Model: model, Messages: conv, Stream: true, Tools: toolDefs}
+	b, _ := json.Marshal(reqBody)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+o.apiKey())
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка соединения с OpenRouter: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		text := buf.String()
+		if len(text) > 200 {
+			text = text[:200]
+		}
+		return nil, fmt.Errorf("ошибка OpenRouter (%d): %s", resp.StatusCode, text)
+	}
+
+	var toolCalls []orToolCall
+	err = readSSE(resp.Body, func(data string) error {
+		var chunk orStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		choice := chunk.Choices[0]
+		if choice.Delta.Content != "" {
+			out <- Delta{Content: choice.Delta.Content}
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			toolCalls = mergeToolCallDelta(toolCalls, tc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toolCalls, nil
+}
@@ -0,0 +1,29 @@
+package backends
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// readSSE scans an SSE body line by line, invoking onData with the payload
+// of every `data:` line (which may be empty). It stops cleanly on a
+// `[DONE]` sentinel or EOF.
+func readSSE(body io.Reader, onData func(data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+		if err := onData(data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+	"github.com/Loischsiy/ChatGavnoAI/history"
+	tele "gopkg.in/telebot.v4"
+)
+
+const (
+	// defaultContextBudget is used for models that don't declare their
+	// own ContextTokens.
+	defaultContextBudget = 8000
+
+	// summarizeFactor controls when maybeSummarize kicks in: once stored
+	// history exceeds the model's budget by this much, older turns get
+	// condensed into one summary message instead of just trimmed away.
+	summarizeFactor = 2
+
+	// historyPageSize is how many messages /history shows per page.
+	historyPageSize = 10
+)
+
+func contextBudget(entry backends.ModelEntry) int {
+	if entry.ContextTokens > 0 {
+		return entry.ContextTokens
+	}
+	return defaultContextBudget
+}
+
+// maybeSummarize condenses everything but the most recent keepTail
+// messages into a single assistant summary message once the stored
+// history grows past summarizeFactor times the model's context budget.
+// It uses the same backend the user is currently talking to, so the
+// summary is in the same "voice" as the conversation.
+func maybeSummarize(userID int64, entry backends.ModelEntry, backend backends.Backend) error {
+	msgs, err := historyStore.Messages(userID)
+	if err != nil {
+		return err
+	}
+	budget := contextBudget(entry)
+	total := 0
+	for _, m := range msgs {
+		total += m.Tokens
+	}
+	if total <= budget*summarizeFactor {
+		return nil
+	}
+
+	const keepTail = 6
+	if len(msgs) <= keepTail {
+		return nil
+	}
+	stale, recent := msgs[:len(msgs)-keepTail], msgs[len(msgs)-keepTail:]
+
+	var transcript strings.Builder
+	for _, m := range stale {
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+	}
+
+	summaryMessages := []backends.Message{{
+		Role:    "user",
+		Content: "Сожми следующий фрагмент диалога в краткое содержание на несколько предложений, сохранив важные факты и договорённости:\n\n" + transcript.String(),
+	}}
+	summary, err := backend.Chat(context.Background(), summaryMessages, backends.ChatOptions{Model: entry.Model})
+	if err != nil {
+		return fmt.Errorf("суммаризация не удалась: %w", err)
+	}
+
+	summarized := append([]history.Message{{
+		Role:      "assistant",
+		Content:   "[Краткое содержание предыдущей части диалога] " + summary,
+		Model:     entry.Model,
+		Tokens:    history.EstimateTokens(summary),
+		CreatedAt: time.Now(),
+	}}, recent...)
+
+	return historyStore.Replace(userID, summarized)
+}
+
+// toBackendMessages converts stored history into backend messages. Images
+// are only carried over when supportsVision is true — a message attached
+// under a vision-capable model can outlive a later switch to one that
+// isn't, and replaying image parts to a model that doesn't support them
+// breaks that turn instead of just losing the picture.
+func toBackendMessages(msgs []history.Message, supportsVision bool) []backends.Message {
+	out := make([]backends.Message, len(msgs))
+	for i, m := range msgs {
+		var images []backends.Image
+		if supportsVision {
+			images = make([]backends.Image, len(m.Images))
+			for j, img := range m.Images {
+				images[j] = backends.Image{MimeType: img.MimeType, Data: img.Data}
+			}
+		}
+		out[i] = backends.Message{Role: m.Role, Content: m.Content, Images: images}
+	}
+	return out
+}
+
+func registerHistoryCommands(b *tele.Bot) {
+	// /clear — wipes the whole conversation.
+	b.Handle("/clear", func(c tele.Context) error {
+		userID := c.Sender().ID
+		if err := historyStore.Clear(userID); err != nil {
+			return c.Send(fmt.Sprintf("Ошибка очистки истории: %v", err))
+		}
+		_ = c.Send("История очищена!")
+		logEntry(userID, "History cleared", "", "")
+		return nil
+	})
+
+	// /forget N — drops the last N turns (both user and assistant
+	// messages count), for walking back a bad exchange without
+	// wiping everything.
+	b.Handle("/forget", func(c tele.Context) error {
+		userID := c.Sender().ID
+		args := c.Args()
+		n := 1
+		if len(args) > 0 {
+			if parsed, err := strconv.Atoi(args[0]); err == nil && parsed > 0 {
+				n = parsed
+			}
+		}
+		if err := historyStore.Forget(userID, n); err != nil {
+			return c.Send(fmt.Sprintf("Ошибка: %v", err))
+		}
+		_ = c.Send(fmt.Sprintf("Удалено последних сообщений: %d", n))
+		logEntry(userID, fmt.Sprintf("Forget %d", n), "", "")
+		return nil
+	})
+
+	// /summarize — condenses history on demand, regardless of the
+	// context budget. Unlike maybeSummarize it keeps no recent turns
+	// verbatim: the whole history is replaced by one summary message.
+	b.Handle("/summarize", func(c tele.Context) error {
+		userID := c.Sender().ID
+		mu.Lock()
+		model := userModels[userID]
+		if model == "" {
+			model = "gpt-3.5"
+		}
+		mu.Unlock()
+
+		entry, ok := backends.Lookup(model)
+		if !ok {
+			return c.Send("Неизвестная модель")
+		}
+		backend, ok := backends.Get(entry.Backend)
+		if !ok {
+			return c.Send("Бэкенд недоступен")
+		}
+
+		msgs, err := historyStore.Messages(userID)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Ошибка: %v", err))
+		}
+		if len(msgs) == 0 {
+			return c.Send("История пуста")
+		}
+
+		var transcript strings.Builder
+		for _, m := range msgs {
+			fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+		}
+		summaryMessages := []backends.Message{{
+			Role:    "user",
+			Content: "Сожми следующий диалог в краткое содержание на несколько предложений:\n\n" + transcript.String(),
+		}}
+		summary, err := backend.Chat(context.Background(), summaryMessages, backends.ChatOptions{Model: entry.Model})
+		if err != nil {
+			return c.Send(fmt.Sprintf("Суммаризация не удалась: %v", err))
+		}
+		replacement := []history.Message{{
+			Role:      "assistant",
+			Content:   "[Краткое содержание диалога] " + summary,
+			Model:     entry.Model,
+			Tokens:    history.EstimateTokens(summary),
+			CreatedAt: time.Now(),
+		}}
+		if err := historyStore.Replace(userID, replacement); err != nil {
+			return c.Send(fmt.Sprintf("Ошибка: %v", err))
+		}
+		_ = c.Send(summary)
+		logEntry(userID, "Command: /summarize", summary, model)
+		return nil
+	})
+
+	// /history [page] — paginates past turns, most recent page first.
+	b.Handle("/history", func(c tele.Context) error {
+		userID := c.Sender().ID
+		msgs, err := historyStore.Messages(userID)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Ошибка: %v", err))
+		}
+		if len(msgs) == 0 {
+			return c.Send("История пуста")
+		}
+
+		pages := (len(msgs) + historyPageSize - 1) / historyPageSize
+		page := pages
+		if args := c.Args(); len(args) > 0 {
+			if parsed, err := strconv.Atoi(args[0]); err == nil && parsed >= 1 && parsed <= pages {
+				page = parsed
+			}
+		}
+
+		start := (page - 1) * historyPageSize
+		end := start + historyPageSize
+		if end > len(msgs) {
+			end = len(msgs)
+		}
+
+		var out strings.Builder
+		fmt.Fprintf(&out, "Страница %d из %d:\n\n", page, pages)
+		for _, m := range msgs[start:end] {
+			fmt.Fprintf(&out, "%s [%s]: %s\n\n", m.Role, m.CreatedAt.Format("02.01 15:04"), m.Content)
+		}
+		fmt.Fprintf(&out, "Используйте /history <номер страницы> для навигации")
+		return c.Send(out.String())
+	})
+
+	// /export — dumps the full conversation as a plain-text file.
+	b.Handle("/export", func(c tele.Context) error {
+		userID := c.Sender().ID
+		msgs, err := historyStore.Messages(userID)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Ошибка: %v", err))
+		}
+		if len(msgs) == 0 {
+			return c.Send("История пуста")
+		}
+
+		var out strings.Builder
+		for _, m := range msgs {
+			fmt.Fprintf(&out, "[%s] %s: %s\n\n", m.CreatedAt.Format("2006-01-02 15:04:05"), m.Role, m.Content)
+		}
+
+		doc := &tele.Document{
+			File:     tele.FromReader(bytes.NewBufferString(out.String())),
+			FileName: fmt.Sprintf("history_%d.txt", userID),
+		}
+		logEntry(userID, "Command: /export", "", "")
+		return c.Send(doc)
+	})
+}
@@ -0,0 +1,81 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is the Store implementation for multi-process deployments,
+// where a single SQLite file on disk isn't shared between instances.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{client: redis.NewClient(&redis.Options{Addr: addr})}
+}
+
+func redisKey(userID int64) string {
+	return fmt.Sprintf("history:%d", userID)
+}
+
+func (s *RedisStore) load(ctx context.Context, userID int64) ([]Message, error) {
+	raw, err := s.client.Get(ctx, redisKey(userID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var msgs []Message
+	if err := json.Unmarshal(raw, &msgs); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (s *RedisStore) save(ctx context.Context, userID int64, msgs []Message) error {
+	raw, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKey(userID), raw, 0).Err()
+}
+
+func (s *RedisStore) Append(userID int64, msg Message) error {
+	ctx := context.Background()
+	msgs, err := s.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+	msgs = append(msgs, msg)
+	return s.save(ctx, userID, msgs)
+}
+
+func (s *RedisStore) Messages(userID int64) ([]Message, error) {
+	return s.load(context.Background(), userID)
+}
+
+func (s *RedisStore) Forget(userID int64, n int) error {
+	ctx := context.Background()
+	msgs, err := s.load(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if n > len(msgs) {
+		n = len(msgs)
+	}
+	msgs = msgs[:len(msgs)-n]
+	return s.save(ctx, userID, msgs)
+}
+
+func (s *RedisStore) Clear(userID int64) error {
+	return s.client.Del(context.Background(), redisKey(userID)).Err()
+}
+
+func (s *RedisStore) Replace(userID int64, msgs []Message) error {
+	return s.save(context.Background(), userID, msgs)
+}
@@ -0,0 +1,156 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore is the default Store: a single local file, no server to
+// run, good enough for a single-process Telegram bot.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("открытие SQLite: %w", err)
+	}
+	// A Telegram bot has many goroutines appending concurrently; WAL plus
+	// a busy timeout turns what would otherwise be sporadic "database is
+	// locked" errors into a short wait, and capping the pool at one
+	// connection keeps writers queued instead of racing into that timeout.
+	if _, err := db.Exec(`PRAGMA journal_mode=WAL; PRAGMA busy_timeout=5000;`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("настройка SQLite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	const schema = `
+		CREATE TABLE IF NOT EXISTS messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			content TEXT NOT NULL,
+			model TEXT NOT NULL,
+			tokens INTEGER NOT NULL,
+			created_at DATETIME NOT NULL,
+			images TEXT
+		);
+		CREATE INDEX IF NOT EXISTS idx_messages_user ON messages(user_id, id);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("создание схемы SQLite: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// encodeImages serializes a message's attachments into the single
+// "images" TEXT column, so image support didn't need a join table.
+func encodeImages(images []Image) (string, error) {
+	if len(images) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(images)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func decodeImages(raw string) ([]Image, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var images []Image
+	if err := json.Unmarshal([]byte(raw), &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (s *SQLiteStore) Append(userID int64, msg Message) error {
+	images, err := encodeImages(msg.Images)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO messages (user_id, role, content, model, tokens, created_at, images) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		userID, msg.Role, msg.Content, msg.Model, msg.Tokens, msg.CreatedAt, images,
+	)
+	return err
+}
+
+func (s *SQLiteStore) Messages(userID int64) ([]Message, error) {
+	rows, err := s.db.Query(
+		`SELECT role, content, model, tokens, created_at, images FROM messages WHERE user_id = ? ORDER BY id ASC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Message
+	for rows.Next() {
+		var m Message
+		var images string
+		if err := rows.Scan(&m.Role, &m.Content, &m.Model, &m.Tokens, &m.CreatedAt, &images); err != nil {
+			return nil, err
+		}
+		if m.Images, err = decodeImages(images); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) Forget(userID int64, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	_, err := s.db.Exec(
+		`DELETE FROM messages WHERE id IN (SELECT id FROM messages WHERE user_id = ? ORDER BY id DESC LIMIT ?)`,
+		userID, n,
+	)
+	return err
+}
+
+func (s *SQLiteStore) Clear(userID int64) error {
+	_, err := s.db.Exec(`DELETE FROM messages WHERE user_id = ?`, userID)
+	return err
+}
+
+func (s *SQLiteStore) Replace(userID int64, msgs []Message) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM messages WHERE user_id = ?`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, m := range msgs {
+		images, err := encodeImages(m.Images)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO messages (user_id, role, content, model, tokens, created_at, images) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			userID, m.Role, m.Content, m.Model, m.Tokens, m.CreatedAt, images,
+		); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,60 @@
+// Package history persists per-user conversations so the bot can survive
+// restarts and manage context windows instead of keeping one growing
+// string per user in memory.
+package history
+
+import "time"
+
+// Image is an inline attachment stored alongside a Message, so
+// vision-capable models can see past photos/documents on later turns.
+type Image struct {
+	MimeType string
+	Data     []byte
+}
+
+// Message is one stored turn of a user's conversation.
+type Message struct {
+	Role      string
+	Content   string
+	Model     string
+	Tokens    int
+	CreatedAt time.Time
+	Images    []Image
+}
+
+// Store persists a per-user sequence of messages. /clear, /forget and
+// /summarize are all just different operations against the same Store;
+// Replace backs /summarize by swapping older turns for a condensed one.
+type Store interface {
+	Append(userID int64, msg Message) error
+	Messages(userID int64) ([]Message, error)
+	Forget(userID int64, n int) error
+	Clear(userID int64) error
+	Replace(userID int64, msgs []Message) error
+}
+
+// EstimateTokens approximates a token count from rune length. It's a
+// rough stand-in for a real tokenizer, good enough for budgeting context
+// windows without pulling in a model-specific one for every provider.
+func EstimateTokens(s string) int {
+	n := len([]rune(s)) / 4
+	if n == 0 && s != "" {
+		n = 1
+	}
+	return n
+}
+
+// Trim drops the oldest messages until the remaining total is at or
+// under budgetTokens, always keeping at least the most recent message.
+func Trim(messages []Message, budgetTokens int) []Message {
+	total := 0
+	for _, m := range messages {
+		total += m.Tokens
+	}
+	start := 0
+	for total > budgetTokens && start < len(messages)-1 {
+		total -= messages[start].Tokens
+		start++
+	}
+	return messages[start:]
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+	"github.com/Loischsiy/ChatGavnoAI/tools"
+	tele "gopkg.in/telebot.v4"
+)
+
+// registerImageCommand wires up /image, which generates a picture through
+// the backend registered for the "image" model key (regardless of the
+// user's selected model) and sends it back as a photo. Rate limiting
+// and prompt validation live in the tools package so the generate_image
+// tool can share them instead of bypassing them.
+func registerImageCommand(b *tele.Bot) {
+	b.Handle("/image", func(c tele.Context) error {
+		userID := c.Sender().ID
+		prompt := strings.Join(c.Args(), " ")
+		if err := tools.ValidateImagePrompt(prompt); err != nil {
+			return c.Send(err.Error())
+		}
+		if !tools.AllowImageGeneration(userID) {
+			return c.Send("Слишком много запросов на генерацию изображений, попробуйте через минуту")
+		}
+
+		entry, ok := backends.Lookup("image")
+		if !ok {
+			return c.Send("Генерация изображений не настроена")
+		}
+		backend, ok := backends.Get(entry.Backend)
+		if !ok {
+			return c.Send("Бэкенд недоступен")
+		}
+
+		_ = c.Send("Генерирую изображение…")
+		data, _, err := backend.GenerateImage(context.Background(), prompt, backends.ChatOptions{Model: entry.Model})
+		if err != nil {
+			logEntry(userID, "Command: /image "+prompt, fmt.Sprintf("error: %v", err), entry.Model)
+			return c.Send(fmt.Sprintf("Ошибка генерации изображения: %v", err))
+		}
+
+		logEntry(userID, "Command: /image "+prompt, "изображение отправлено", entry.Model)
+		return c.Send(&tele.Photo{File: tele.FromReader(bytes.NewReader(data))})
+	})
+}
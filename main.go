@@ -1,39 +1,30 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"os"
-	"os/exec"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+	"github.com/Loischsiy/ChatGavnoAI/history"
+	"github.com/Loischsiy/ChatGavnoAI/tools"
 	"github.com/joho/godotenv"
 	tele "gopkg.in/telebot.v4"
 )
 
-type ModelInfo struct {
-	Provider string
-	Name     string
-}
-
 var (
-	memory     = make(map[int64]string)
 	userModels = make(map[int64]string)
 	mu         sync.Mutex
 
-	MODELS_INFO = map[string]ModelInfo{
-		"gpt-3.5":  {Provider: "openrouter", Name: "openai/gpt-3.5-turbo"},
-		"gpt-4":    {Provider: "openrouter", Name: "openai/gpt-4o"},
-		"gemini":   {Provider: "gemini", Name: "gemini-2.0-flash"},
-		"deepseek": {Provider: "openrouter", Name: "deepseek/deepseek-r1"},
-		"qwen":     {Provider: "openrouter", Name: "qwen/qwen-plus"},
-		"claude":   {Provider: "openrouter", Name: "anthropic/claude-3.5-haiku"},
-		// "image/sora_v2": {Provider: "selenium", Name: "image/sora_v2"},
-	}
+	historyStore history.Store
+
+	// editInterval bounds how often a streaming reply edits the
+	// Telegram message, so a long reply doesn't hammer the bot API's
+	// rate limit on message edits.
+	editInterval = 800 * time.Millisecond
 )
 
 const LOG_FILE = "bot.log"
@@ -65,7 +56,7 @@ func modelKeyboard() *tele.ReplyMarkup {
 	m := &tele.ReplyMarkup{}
 	rows := [][]tele.InlineButton{}
 	row := []tele.InlineButton{}
-	for model := range MODELS_INFO {
+	for _, model := range backends.ModelKeys() {
 		btn := tele.InlineButton{Text: model, Data: "set_" + model}
 		row = append(row, btn)
 		if len(row) == 2 {
@@ -81,141 +72,28 @@ func modelKeyboard() *tele.ReplyMarkup {
 	return m
 }
 
-// OpenRouter request/response types
-type orMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type orRequest struct {
-	Model    string      `json:"model"`
-	Messages []orMessage `json:"messages"`
-}
-
-type orResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
-
-// Gemini request/response types
-type geminiRequest struct {
-	Contents []struct {
-		Parts []struct {
-			Text string `json:"text"`
-		} `json:"parts"`
-	} `json:"contents"`
-}
-
-type geminiResponse struct {
-	Candidates []struct {
-		Content struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-		} `json:"content"`
-	} `json:"candidates"`
-}
-
-func handleOpenRouter(model, prompt, apiKey string) string {
-	if apiKey == "" {
-		return "Ошибка: отсутствует OPENROUTER_API_KEY"
-	}
-	reqBody := orRequest{
-		Model: model,
-		Messages: []orMessage{{
-			Role:    "user",
-			Content: prompt,
-		}},
-	}
-	b, _ := json.Marshal(reqBody)
-	httpReq, _ := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(b))
-	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Sprintf("Ошибка соединения с OpenRouter: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		buf := new(bytes.Buffer)
-		_, _ = buf.ReadFrom(resp.Body)
-		text := buf.String()
-		if len(text) > 200 {
-			text = text[:200]
-		}
-		return fmt.Sprintf("Ошибка OpenRouter (%d): %s", resp.StatusCode, text)
-	}
-	var orResp orResponse
-	if err := json.NewDecoder(resp.Body).Decode(&orResp); err != nil {
-		return fmt.Sprintf("Некорректный ответ от OpenRouter: %v", err)
-	}
-	if len(orResp.Choices) == 0 {
-		return "Некорректный ответ от OpenRouter: пустые choices"
-	}
-	return orResp.Choices[0].Message.Content
-}
-
-func handleGemini(prompt, apiKey string) string {
-	if apiKey == "" {
-		return "Ошибка: отсутствует GEMINI_API_KEY"
-	}
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/gemini-2.0-flash:generateContent?key=%s", apiKey)
-	req := geminiRequest{
-		Contents: []struct {
-			Parts []struct {
-				Text string `json:"text"`
-			} `json:"parts"`
-		}{
-			{
-				Parts: []struct {
-					Text string `json:"text"`
-				}{
-					{Text: prompt},
-				},
-			},
-		},
-	}
-	b, _ := json.Marshal(req)
-	httpReq, _ := http.NewRequest("POST", url, bytes.NewBuffer(b))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		return fmt.Sprintf("Ошибка API Gemini: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "Ошибка API Gemini"
-	}
-	var gr geminiResponse
-	if err := json.NewDecoder(resp.Body).Decode(&gr); err != nil {
-		return fmt.Sprintf("Ошибка разбора ответа Gemini: %v", err)
-	}
-	if len(gr.Candidates) == 0 || len(gr.Candidates[0].Content.Parts) == 0 {
-		return "Пустой ответ от Gemini"
-	}
-	return gr.Candidates[0].Content.Parts[0].Text
-}
-
 func main() {
 	// Load environment variables from .env in current working directory
 	_ = godotenv.Load()
 
 	token := os.Getenv("TOKEN")
-	geminiKey := os.Getenv("GEMINI_API_KEY")
-	openrouterKey := os.Getenv("OPENROUTER_API_KEY")
 
 	if token == "" {
 		fmt.Println("Ошибка: переменная среды TOKEN не задана")
 		return
 	}
 
+	store, err := newHistoryStore()
+	if err != nil {
+		fmt.Printf("Не удалось инициализировать хранилище истории: %v\n", err)
+		return
+	}
+	historyStore = store
+
+	if addr := os.Getenv("HTTP_ADDR"); addr != "" {
+		go serveHTTPGateway(addr)
+	}
+
 	pref := tele.Settings{
 		Token:  token,
 		Poller: &tele.LongPoller{Timeout: 10 * time.Second},
@@ -228,14 +106,14 @@ func main() {
 
 	// /start и /help
 	b.Handle("/start", func(c tele.Context) error {
-		txt := "Привет! Я мульти-модельный AI бот\nДоступные команды:\n/model - Выбрать модель\n/clear - Очистить историю"
+		txt := "Привет! Я мульти-модельный AI бот\nДоступные команды:\n/model - Выбрать модель\n/image <описание> - Сгенерировать изображение\n/clear - Очистить историю\n/forget N - Забыть последние N сообщений\n/summarize - Сжать историю в краткое содержание\n/history - Показать прошлые сообщения\n/export - Выгрузить историю файлом"
 		_ = c.Send(txt)
 		logEntry(c.Sender().ID, "Command: /start", "", "")
 		return nil
 	})
 
 	b.Handle("/help", func(c tele.Context) error {
-		txt := "Привет! Я мульти-модельный AI бот\nДоступные команды:\n/model - Выбрать модель\n/clear - Очистить историю"
+		txt := "Привет! Я мульти-модельный AI бот\nДоступные команды:\n/model - Выбрать модель\n/image <описание> - Сгенерировать изображение\n/clear - Очистить историю\n/forget N - Забыть последние N сообщений\n/summarize - Сжать историю в краткое содержание\n/history - Показать прошлые сообщения\n/export - Выгрузить историю файлом"
 		_ = c.Send(txt)
 		logEntry(c.Sender().ID, "Command: /help", "", "")
 		return nil
@@ -261,23 +139,19 @@ func main() {
 			userModels[c.Sender().ID] = model
 			mu.Unlock()
 			_ = c.Edit(fmt.Sprintf("Модель изменена на %s", model))
-			if model == "image/sora_v2" {
-				_ = c.Send("Опишите изображение, которое вы хотите сгенерировать")
-			}
 			logEntry(c.Sender().ID, fmt.Sprintf("Model changed to %s", model), "", "")
 		}
 		return c.Respond()
 	})
 
-	// /clear
-	b.Handle("/clear", func(c tele.Context) error {
-		mu.Lock()
-		memory[c.Sender().ID] = ""
-		mu.Unlock()
-		_ = c.Send("История очищена!")
-		logEntry(c.Sender().ID, "History cleared", "", "")
-		return nil
-	})
+	// /image
+	registerImageCommand(b)
+
+	// /clear, /forget, /summarize, /history, /export
+	registerHistoryCommands(b)
+
+	// Photos and documents, for vision-capable models
+	registerMediaHandlers(b)
 
 	// Основной обработчик текста
 	b.Handle(tele.OnText, func(c tele.Context) error {
@@ -287,44 +161,102 @@ func main() {
 		if model == "" {
 			model = "gpt-3.5"
 		}
-		history := memory[userID]
-		history += "\nUser: " + c.Text()
-		memory[userID] = history
 		mu.Unlock()
 
-		var response string
-		info := MODELS_INFO[model]
-		prompt := history
-
-		switch info.Provider {
-		case "gemini":
-			response = handleGemini(prompt, geminiKey)
-		case "openrouter":
-			response = handleOpenRouter(info.Name, prompt, openrouterKey)
-		case "selenium":
-			// Send initial status to user
-			_ = c.Send("Отправляю запрос в Sora. Ожидайте генерации…")
-			// Pass user's text as prompt to Python Selenium runner
-			cmd := exec.Command("bash", "-c", fmt.Sprintf("source venv/bin/activate && python3 sora_runner.py --prompt %q", c.Text()))
-			out, err := cmd.CombinedOutput()
-			if err != nil {
-				response = fmt.Sprintf("Ошибка запуска Selenium: %v\n%s", err, string(out))
-			} else {
-				// The Python script prints markers: WAITING then DONE
-				// We simply confirm completion here.
-				response = "Генерация завершена"
-			}
-		default:
-			response = "Неизвестная модель"
+		entry, ok := backends.Lookup(model)
+		if !ok {
+			logEntry(userID, c.Text(), "Неизвестная модель", model)
+			return c.Send("Неизвестная модель")
+		}
+		backend, ok := backends.Get(entry.Backend)
+		if !ok {
+			logEntry(userID, c.Text(), "Бэкенд недоступен", model)
+			return c.Send("Бэкенд недоступен")
 		}
 
-		mu.Lock()
-		memory[userID] = memory[userID] + "\nAI: " + response
-		mu.Unlock()
+		userMsg := history.Message{
+			Role:      "user",
+			Content:   c.Text(),
+			Model:     model,
+			Tokens:    history.EstimateTokens(c.Text()),
+			CreatedAt: time.Now(),
+		}
+		if err := historyStore.Append(userID, userMsg); err != nil {
+			return c.Send(fmt.Sprintf("Ошибка записи истории: %v", err))
+		}
+		if err := maybeSummarize(userID, entry, backend); err != nil {
+			fmt.Printf("Ошибка суммаризации: %v\n", err)
+		}
+
+		msgs, err := historyStore.Messages(userID)
+		if err != nil {
+			return c.Send(fmt.Sprintf("Ошибка чтения истории: %v", err))
+		}
+		msgs = history.Trim(msgs, contextBudget(entry))
+
+		messages := toBackendMessages(msgs, entry.SupportsVision)
+		opts := backends.ChatOptions{Model: entry.Model, Tools: toolSchemas(), InvokeTool: invokeTool}
+
+		response, delivered := respond(b, c, backend, messages, opts)
+
+		aiMsg := history.Message{
+			Role:      "assistant",
+			Content:   response,
+			Model:     model,
+			Tokens:    history.EstimateTokens(response),
+			CreatedAt: time.Now(),
+		}
+		if err := historyStore.Append(userID, aiMsg); err != nil {
+			fmt.Printf("Ошибка записи истории: %v\n", err)
+		}
 
 		logEntry(userID, c.Text(), response, model)
+		if delivered {
+			return nil
+		}
 		return c.Send(response)
 	})
 
 	b.Start()
 }
+
+// newHistoryStore picks the persistence backend from HISTORY_BACKEND
+// (default "sqlite"; "redis" uses REDIS_ADDR).
+func newHistoryStore() (history.Store, error) {
+	switch os.Getenv("HISTORY_BACKEND") {
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return history.NewRedisStore(addr), nil
+	default:
+		path := os.Getenv("HISTORY_DB_PATH")
+		if path == "" {
+			path = "history.db"
+		}
+		return history.NewSQLiteStore(path)
+	}
+}
+
+// respond routes a chat turn through backend, preferring Stream (with
+// throttled Telegram message edits) and falling back to a single Chat
+// call when the backend has no streaming support. It reports whether the
+// response was already delivered to the user (true for streamed
+// replies), so the caller doesn't send it a second time.
+func respond(bot *tele.Bot, c tele.Context, backend backends.Backend, messages []backends.Message, opts backends.ChatOptions) (string, bool) {
+	ctx := tools.ContextWithUserID(context.Background(), c.Sender().ID)
+
+	stream, err := backend.Stream(ctx, messages, opts)
+	if err == backends.ErrStreamingUnsupported {
+		text, err := backend.Chat(ctx, messages, opts)
+		if err != nil {
+			return fmt.Sprintf("Ошибка: %v", err), false
+		}
+		return text, false
+	}
+	if err != nil {
+		return fmt.Sprintf("Ошибка: %v", err), false
+	}
+	return streamResponse(bot, c, stream), true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+	"github.com/Loischsiy/ChatGavnoAI/history"
+	tele "gopkg.in/telebot.v4"
+)
+
+// maxMediaBytes bounds how much of a photo/document we pull into memory;
+// Telegram's own bot API already caps downloadable files at 20MB.
+const maxMediaBytes = 20 << 20
+
+// registerMediaHandlers wires up photo and document uploads as
+// multimodal turns, gated on the current model's vision support.
+func registerMediaHandlers(b *tele.Bot) {
+	b.Handle(tele.OnPhoto, func(c tele.Context) error {
+		photo := c.Message().Photo
+		if photo == nil {
+			return nil
+		}
+		return handleMedia(b, c, photo.File, "image/jpeg", c.Message().Caption)
+	})
+
+	b.Handle(tele.OnDocument, func(c tele.Context) error {
+		doc := c.Message().Document
+		if doc == nil {
+			return nil
+		}
+		return handleMedia(b, c, doc.File, doc.MIME, c.Message().Caption)
+	})
+}
+
+func handleMedia(b *tele.Bot, c tele.Context, file tele.File, mimeType, caption string) error {
+	userID := c.Sender().ID
+	mu.Lock()
+	model := userModels[userID]
+	if model == "" {
+		model = "gpt-3.5"
+	}
+	mu.Unlock()
+
+	entry, ok := backends.Lookup(model)
+	if !ok {
+		return c.Send("Неизвестная модель")
+	}
+	if !entry.SupportsVision {
+		return c.Send("Текущая модель не принимает изображения и файлы. Выберите vision-модель через /model")
+	}
+	backend, ok := backends.Get(entry.Backend)
+	if !ok {
+		return c.Send("Бэкенд недоступен")
+	}
+
+	reader, err := b.File(&file)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка загрузки файла: %v", err))
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxMediaBytes))
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка чтения файла: %v", err))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	userMsg := history.Message{
+		Role:      "user",
+		Content:   caption,
+		Model:     model,
+		Tokens:    history.EstimateTokens(caption),
+		CreatedAt: time.Now(),
+		Images:    []history.Image{{MimeType: mimeType, Data: data}},
+	}
+	if err := historyStore.Append(userID, userMsg); err != nil {
+		return c.Send(fmt.Sprintf("Ошибка записи истории: %v", err))
+	}
+	if err := maybeSummarize(userID, entry, backend); err != nil {
+		fmt.Printf("Ошибка суммаризации: %v\n", err)
+	}
+
+	msgs, err := historyStore.Messages(userID)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка чтения истории: %v", err))
+	}
+	msgs = history.Trim(msgs, contextBudget(entry))
+
+	messages := toBackendMessages(msgs, entry.SupportsVision)
+	opts := backends.ChatOptions{Model: entry.Model, Tools: toolSchemas(), InvokeTool: invokeTool}
+
+	response, delivered := respond(b, c, backend, messages, opts)
+
+	aiMsg := history.Message{
+		Role:      "assistant",
+		Content:   response,
+		Model:     model,
+		Tokens:    history.EstimateTokens(response),
+		CreatedAt: time.Now(),
+	}
+	if err := historyStore.Append(userID, aiMsg); err != nil {
+		fmt.Printf("Ошибка записи истории: %v\n", err)
+	}
+
+	logEntry(userID, "[вложение] "+caption, response, model)
+	if delivered {
+		return nil
+	}
+	return c.Send(response)
+}
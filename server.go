@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+)
+
+// serveHTTPGateway exposes the same backend registry the Telegram handler
+// uses through an OpenAI-compatible HTTP API, so tools built against
+// go-openai, LangChain or Continue can point at this process instead of
+// OpenAI directly and reuse the bot's model routing.
+func serveHTTPGateway(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", handleListModels)
+	mux.HandleFunc("/v1/chat/completions", handleChatCompletions)
+	mux.HandleFunc("/v1/completions", handleCompletions)
+
+	fmt.Printf("HTTP-шлюз слушает %s\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Printf("Ошибка HTTP-шлюза: %v\n", err)
+	}
+}
+
+type oaiMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type oaiChatRequest struct {
+	Model    string       `json:"model"`
+	Messages []oaiMessage `json:"messages"`
+	Stream   bool         `json:"stream"`
+}
+
+type oaiCompletionRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+	Stream bool   `json:"stream"`
+}
+
+type oaiChoice struct {
+	Index        int        `json:"index"`
+	Message      oaiMessage `json:"message"`
+	FinishReason string     `json:"finish_reason"`
+}
+
+type oaiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type oaiChatResponse struct {
+	ID      string      `json:"id"`
+	Object  string      `json:"object"`
+	Created int64       `json:"created"`
+	Model   string      `json:"model"`
+	Choices []oaiChoice `json:"choices"`
+	Usage   oaiUsage    `json:"usage"`
+}
+
+type oaiStreamDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type oaiStreamChoice struct {
+	Index        int            `json:"index"`
+	Delta        oaiStreamDelta `json:"delta"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+type oaiStreamChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"`
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []oaiStreamChoice `json:"choices"`
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"message": message},
+	})
+}
+
+func handleListModels(w http.ResponseWriter, r *http.Request) {
+	data := make([]map[string]any, 0, len(backends.ModelKeys()))
+	for _, key := range backends.ModelKeys() {
+		data = append(data, map[string]any{
+			"id":       key,
+			"object":   "model",
+			"created":  0,
+			"owned_by": "chatgavnoai",
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data})
+}
+
+func resolveBackend(model string) (backends.Backend, backends.ModelEntry, error) {
+	entry, ok := backends.Lookup(model)
+	if !ok {
+		return nil, entry, fmt.Errorf("неизвестная модель: %s", model)
+	}
+	backend, ok := backends.Get(entry.Backend)
+	if !ok {
+		return nil, entry, fmt.Errorf("бэкенд недоступен: %s", entry.Backend)
+	}
+	return backend, entry, nil
+}
+
+func handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "ожидается POST")
+		return
+	}
+	var req oaiChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("некорректный JSON: %v", err))
+		return
+	}
+	backend, entry, err := resolveBackend(req.Model)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	messages := make([]backends.Message, len(req.Messages))
+	for i, m := range req.Messages {
+		messages[i] = backends.Message{Role: m.Role, Content: m.Content}
+	}
+	opts := backends.ChatOptions{Model: entry.Model, Tools: toolSchemas(), InvokeTool: invokeTool}
+
+	if req.Stream {
+		streamChatCompletion(w, backend, messages, opts, req.Model)
+		return
+	}
+
+	text, err := backend.Chat(r.Context(), messages, opts)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeChatCompletion(w, req.Model, text)
+}
+
+func handleCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "ожидается POST")
+		return
+	}
+	var req oaiCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("некорректный JSON: %v", err))
+		return
+	}
+	backend, entry, err := resolveBackend(req.Model)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	messages := []backends.Message{{Role: "user", Content: req.Prompt}}
+	opts := backends.ChatOptions{Model: entry.Model, Tools: toolSchemas(), InvokeTool: invokeTool}
+
+	if req.Stream {
+		streamChatCompletion(w, backend, messages, opts, req.Model)
+		return
+	}
+
+	text, err := backend.Chat(r.Context(), messages, opts)
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeChatCompletion(w, req.Model, text)
+}
+
+func writeChatCompletion(w http.ResponseWriter, model, text string) {
+	resp := oaiChatResponse{
+		ID:      fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano()),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []oaiChoice{{
+			Index:        0,
+			Message:      oaiMessage{Role: "assistant", Content: text},
+			FinishReason: "stop",
+		}},
+		Usage: oaiUsage{
+			PromptTokens:     0,
+			CompletionTokens: 0,
+			TotalTokens:      0,
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func streamChatCompletion(w http.ResponseWriter, backend backends.Backend, messages []backends.Message, opts backends.ChatOptions, model string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "потоковая передача не поддерживается сервером")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := backend.Stream(ctx, messages, opts)
+	if err == backends.ErrStreamingUnsupported {
+		text, chatErr := backend.Chat(ctx, messages, opts)
+		if chatErr != nil {
+			writeJSONError(w, http.StatusBadGateway, chatErr.Error())
+			return
+		}
+		stream = singleDeltaChan(text)
+	} else if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	id := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	writer := bufio.NewWriter(w)
+
+	for delta := range stream {
+		if delta.Err != nil {
+			continue
+		}
+		chunk := oaiStreamChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []oaiStreamChoice{{Index: 0, Delta: oaiStreamDelta{Content: delta.Content}}},
+		}
+		b, _ := json.Marshal(chunk)
+		fmt.Fprintf(writer, "data: %s\n\n", b)
+		writer.Flush()
+		flusher.Flush()
+	}
+
+	finish := "stop"
+	final := oaiStreamChunk{
+		ID:      id,
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []oaiStreamChoice{{Index: 0, Delta: oaiStreamDelta{}, FinishReason: &finish}},
+	}
+	b, _ := json.Marshal(final)
+	fmt.Fprintf(writer, "data: %s\n\ndata: [DONE]\n\n", b)
+	writer.Flush()
+	flusher.Flush()
+}
+
+func singleDeltaChan(text string) <-chan backends.Delta {
+	out := make(chan backends.Delta, 1)
+	out <- backends.Delta{Content: text}
+	close(out)
+	return out
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+	tele "gopkg.in/telebot.v4"
+)
+
+// streamResponse sends a placeholder message, then progressively edits it
+// as deltas arrive on stream, and returns the full response text once the
+// channel closes (for history storage and logging).
+func streamResponse(bot *tele.Bot, c tele.Context, stream <-chan backends.Delta) string {
+	placeholder, err := bot.Send(c.Recipient(), "…")
+	if err != nil {
+		return fmt.Sprintf("Ошибка отправки сообщения: %v", err)
+	}
+	editor := newThrottledEditor(bot, placeholder, editInterval)
+
+	var full strings.Builder
+	var streamErr error
+	for delta := range stream {
+		if delta.Err != nil {
+			streamErr = delta.Err
+			continue
+		}
+		full.WriteString(delta.Content)
+		// A transient failure editing one intermediate message doesn't
+		// invalidate the stream — Finish below still gets the final word,
+		// so don't let it clobber a response that otherwise completes fine.
+		_ = editor.Append(delta.Content)
+	}
+
+	if full.Len() == 0 {
+		errText := "Пустой ответ от модели"
+		if streamErr != nil {
+			errText = fmt.Sprintf("Ошибка потоковой передачи: %v", streamErr)
+		}
+		_, _ = bot.Edit(placeholder, errText)
+		return errText
+	}
+	if err := editor.Finish(); err != nil {
+		// The full response was still received — only the last Telegram
+		// edit failed to land, which is the same kind of transient hiccup
+		// as any other. Try once more to show it, but return the real
+		// answer either way so history/log don't lose it to a delivery
+		// failure.
+		_, _ = bot.Edit(placeholder, full.String())
+	}
+	return full.String()
+}
+
+// throttledEditor coalesces fast-arriving deltas into Telegram message
+// edits no more often than `interval`, so we don't trip the bot API's
+// rate limits while a model is streaming a long reply.
+type throttledEditor struct {
+	bot      *tele.Bot
+	msg      *tele.Message
+	interval time.Duration
+	last     time.Time
+	buf      strings.Builder
+	sent     string
+}
+
+func newThrottledEditor(bot *tele.Bot, msg *tele.Message, interval time.Duration) *throttledEditor {
+	return &throttledEditor{bot: bot, msg: msg, interval: interval}
+}
+
+// Append buffers a delta and, if enough time has passed since the last
+// edit, flushes the accumulated text to Telegram.
+func (t *throttledEditor) Append(delta string) error {
+	t.buf.WriteString(delta)
+	if time.Since(t.last) < t.interval {
+		return nil
+	}
+	return t.flush()
+}
+
+func (t *throttledEditor) flush() error {
+	text := t.buf.String()
+	if text == "" || text == t.sent {
+		return nil
+	}
+	t.last = time.Now()
+	t.sent = text
+	_, err := t.bot.Edit(t.msg, text)
+	return err
+}
+
+// Finish flushes any buffered text that didn't meet the throttle
+// interval, guaranteeing the final message reflects the full response.
+func (t *throttledEditor) Finish() error {
+	return t.flush()
+}
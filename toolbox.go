@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+	"github.com/Loischsiy/ChatGavnoAI/tools"
+)
+
+// toolSchemas exposes every registered tool to a backend in its own
+// ToolSchema shape, with local $refs resolved since not every provider
+// dereferences them server-side.
+func toolSchemas() []backends.ToolSchema {
+	all := tools.All()
+	out := make([]backends.ToolSchema, len(all))
+	for i, t := range all {
+		out[i] = backends.ToolSchema{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Parameters:  tools.ResolveRefs(t.JSONSchema()),
+		}
+	}
+	return out
+}
+
+// invokeTool dispatches a model-issued tool call to the tools registry;
+// it's the InvokeTool callback wired into ChatOptions.
+func invokeTool(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	return tools.Invoke(ctx, name, args)
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(&calculator{})
+}
+
+// calculator evaluates basic arithmetic expressions. It's a small
+// hand-rolled parser rather than a shelled-out interpreter, so a model
+// can't use it to run arbitrary commands on the host.
+type calculator struct{}
+
+func (*calculator) Name() string { return "calculator" }
+func (*calculator) Description() string {
+	return "Вычисляет арифметическое выражение (+, -, *, /, скобки)."
+}
+
+func (*calculator) JSONSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"expression": {"type": "string", "description": "Например: (2 + 3) * 4"}
+		},
+		"required": ["expression"]
+	}`)
+}
+
+func (*calculator) Invoke(ctx context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Expression string `json:"expression"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("некорректные аргументы: %w", err)
+	}
+	result, err := evalExpression(params.Expression)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(result, 'g', -1, 64), nil
+}
+
+// exprParser is a minimal recursive-descent parser for
+// expr := term (('+' | '-') term)*
+// term := factor (('*' | '/') factor)*
+// factor := number | '(' expr ')' | ('-' | '+') factor
+type exprParser struct {
+	input string
+	pos   int
+}
+
+func evalExpression(input string) (float64, error) {
+	p := &exprParser{input: strings.TrimSpace(input)}
+	result, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.input) {
+		return 0, fmt.Errorf("неожиданный символ на позиции %d", p.pos)
+	}
+	return result, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			break
+		}
+		op := p.input[p.pos]
+		if op != '+' && op != '-' {
+			break
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			break
+		}
+		op := p.input[p.pos]
+		if op != '*' && op != '/' {
+			break
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+		if op == '*' {
+			left *= right
+		} else {
+			if right == 0 {
+				return 0, fmt.Errorf("деление на ноль")
+			}
+			left /= right
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseFactor() (float64, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0, fmt.Errorf("неожиданный конец выражения")
+	}
+	switch p.input[p.pos] {
+	case '+':
+		p.pos++
+		return p.parseFactor()
+	case '-':
+		p.pos++
+		v, err := p.parseFactor()
+		return -v, err
+	case '(':
+		p.pos++
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		p.skipSpaces()
+		if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+			return 0, fmt.Errorf("ожидалась закрывающая скобка")
+		}
+		p.pos++
+		return v, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, fmt.Errorf("ожидалось число на позиции %d", p.pos)
+	}
+	return strconv.ParseFloat(p.input[start:p.pos], 64)
+}
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Loischsiy/ChatGavnoAI/backends"
+)
+
+func init() {
+	Register(&imageGen{})
+}
+
+// imageGen lets the model trigger image generation through the registry's
+// "image" model entry, the same one the /image command uses.
+type imageGen struct{}
+
+func (*imageGen) Name() string { return "generate_image" }
+func (*imageGen) Description() string {
+	return "Генерирует изображение по текстовому описанию."
+}
+
+func (*imageGen) JSONSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"prompt": {"type": "string", "description": "Описание желаемого изображения"}
+		},
+		"required": ["prompt"]
+	}`)
+}
+
+func (*imageGen) Invoke(ctx context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Prompt string `json:"prompt"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("некорректные аргументы: %w", err)
+	}
+	if err := ValidateImagePrompt(params.Prompt); err != nil {
+		return "", err
+	}
+	// Share the /image command's per-user throttle so a model can't use
+	// tool-calling (up to maxToolIterations rounds per chat turn) to drive
+	// unlimited paid generations past it. Calls whose context carries no
+	// user ID, such as those from the HTTP gateway, are not throttled.
+	if userID, ok := UserIDFromContext(ctx); ok && !AllowImageGeneration(userID) {
+		return "", fmt.Errorf("слишком много запросов на генерацию изображений, попробуйте позже")
+	}
+
+	entry, ok := backends.Lookup("image")
+	if !ok {
+		return "", fmt.Errorf("модель генерации изображений не настроена")
+	}
+	backend, ok := backends.Get(entry.Backend)
+	if !ok {
+		return "", fmt.Errorf("бэкенд генерации изображений недоступен")
+	}
+	data, _, err := backend.GenerateImage(ctx, params.Prompt, backends.ChatOptions{Model: entry.Model})
+	if err != nil {
+		return "", fmt.Errorf("генерация не удалась: %w", err)
+	}
+	// Tool results are text-only, so the image itself can't travel back
+	// through this channel; the user gets the file via the /image command.
+	return fmt.Sprintf("Изображение сгенерировано (%d байт). Используйте команду /image, чтобы получить файл.", len(data)), nil
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxImagePromptLen bounds how much text a prompt can carry; providers
+	// reject absurdly long prompts anyway, but we'd rather fail with a
+	// clear message than wait on the round trip.
+	maxImagePromptLen = 2000
+
+	// imageRateLimitCount/imageRateLimitWindow bound how many generations a
+	// single user can trigger per window, since each call is a paid,
+	// comparatively slow request against a provider API — whether it comes
+	// from the /image command or from the model calling generate_image.
+	imageRateLimitCount  = 3
+	imageRateLimitWindow = time.Minute
+)
+
+// imageRateLimiter throttles image generation per user across the whole
+// process, shared by the /image command and the generate_image tool.
+var imageRateLimiter = newRateLimiter(imageRateLimitCount, imageRateLimitWindow)
+
+// rateLimiter is a simple sliding-window limiter keyed by user ID: it
+// counts a user's calls within the last `window` before each new one.
+type rateLimiter struct {
+	mu     sync.Mutex
+	hits   map[int64][]time.Time
+	limit  int
+	window time.Duration
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{hits: make(map[int64][]time.Time), limit: limit, window: window}
+}
+
+// Allow reports whether userID may make another call right now, recording
+// the attempt if so.
+func (r *rateLimiter) Allow(userID int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-r.window)
+	kept := r.hits[userID][:0]
+	for _, t := range r.hits[userID] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) >= r.limit {
+		r.hits[userID] = kept
+		return false
+	}
+	r.hits[userID] = append(kept, time.Now())
+	return true
+}
+
+// AllowImageGeneration reports whether userID may trigger another image
+// generation right now, recording the attempt if so. Shared by the /image
+// command and the generate_image tool so neither can bypass the other's
+// throttling.
+func AllowImageGeneration(userID int64) bool {
+	return imageRateLimiter.Allow(userID)
+}
+
+// ValidateImagePrompt rejects prompts that are empty or unreasonably long
+// before they're ever sent to a provider.
+func ValidateImagePrompt(prompt string) error {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return fmt.Errorf("опишите, что сгенерировать")
+	}
+	if len([]rune(prompt)) > maxImagePromptLen {
+		return fmt.Errorf("описание слишком длинное (максимум %d символов)", maxImagePromptLen)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package tools
+
+import "encoding/json"
+
+// ResolveRefs inlines local "$ref": "#/$defs/Name" (and the older
+// "#/definitions/Name") pointers in a JSON Schema document, so providers
+// that don't dereference schemas themselves still see a usable shape.
+// Refs outside the document are left untouched.
+func ResolveRefs(schema json.RawMessage) json.RawMessage {
+	var root map[string]any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return schema
+	}
+	resolved := resolveNode(root, root, 0)
+	out, err := json.Marshal(resolved)
+	if err != nil {
+		return schema
+	}
+	return out
+}
+
+const maxRefDepth = 10
+
+func resolveNode(node any, root map[string]any, depth int) any {
+	if depth > maxRefDepth {
+		return node
+	}
+	switch v := node.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok {
+			if target, ok := lookupRef(root, ref); ok {
+				return resolveNode(target, root, depth+1)
+			}
+			return v
+		}
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = resolveNode(val, root, depth)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = resolveNode(val, root, depth)
+		}
+		return out
+	default:
+		return node
+	}
+}
+
+func lookupRef(root map[string]any, ref string) (any, bool) {
+	const prefixDefs = "#/$defs/"
+	const prefixDefinitions = "#/definitions/"
+	var key, name string
+	switch {
+	case len(ref) > len(prefixDefs) && ref[:len(prefixDefs)] == prefixDefs:
+		key, name = "$defs", ref[len(prefixDefs):]
+	case len(ref) > len(prefixDefinitions) && ref[:len(prefixDefinitions)] == prefixDefinitions:
+		key, name = "definitions", ref[len(prefixDefinitions):]
+	default:
+		return nil, false
+	}
+	defs, ok := root[key].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	target, ok := defs[name]
+	return target, ok
+}
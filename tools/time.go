@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+func init() {
+	Register(&currentTime{})
+}
+
+// currentTime reports the current date and time, since models have no
+// reliable notion of "now" on their own.
+type currentTime struct{}
+
+func (*currentTime) Name() string { return "current_time" }
+func (*currentTime) Description() string {
+	return "Возвращает текущие дату и время (UTC)."
+}
+
+func (*currentTime) JSONSchema() json.RawMessage {
+	return json.RawMessage(`{"type": "object", "properties": {}}`)
+}
+
+func (*currentTime) Invoke(ctx context.Context, args json.RawMessage) (string, error) {
+	return time.Now().UTC().Format(time.RFC3339), nil
+}
@@ -0,0 +1,73 @@
+// Package tools implements function/tool-calling: a registry of callable
+// tools the model can invoke mid-conversation, each describing itself
+// with a JSON schema the provider forwards to the model.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Tool is a single callable function exposed to the model.
+type Tool interface {
+	Name() string
+	Description() string
+	// JSONSchema returns the tool's parameters as a JSON Schema object,
+	// e.g. {"type":"object","properties":{...},"required":[...]}.
+	JSONSchema() json.RawMessage
+	Invoke(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// ctxKey avoids collisions with context keys other packages might use.
+type ctxKey int
+
+const userIDKey ctxKey = 0
+
+// ContextWithUserID attaches the ID of the user whose conversation turn is
+// triggering a tool call, so a tool that needs per-user limits (like
+// generate_image) can look it up without widening the Tool interface for
+// everyone else.
+func ContextWithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext retrieves the user ID set by ContextWithUserID, if any.
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDKey).(int64)
+	return id, ok
+}
+
+var registry = map[string]Tool{}
+
+// Register makes a tool callable by name. Call from the tool's init().
+func Register(t Tool) {
+	registry[t.Name()] = t
+}
+
+// Get returns the tool registered under name, if any.
+func Get(name string) (Tool, bool) {
+	t, ok := registry[name]
+	return t, ok
+}
+
+// All returns every registered tool, in no particular order.
+func All() []Tool {
+	out := make([]Tool, 0, len(registry))
+	for _, t := range registry {
+		out = append(out, t)
+	}
+	return out
+}
+
+// Invoke dispatches a model-issued tool call to the registered tool by
+// name, returning an error when the tool is unknown. The backends pass
+// that error's text back to the model as the tool result, so a
+// misbehaving model sees why the call didn't work.
+func Invoke(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	t, ok := Get(name)
+	if !ok {
+		return "", fmt.Errorf("неизвестный инструмент: %s", name)
+	}
+	return t.Invoke(ctx, args)
+}
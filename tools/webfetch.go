@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(&webFetch{})
+}
+
+// maxFetchBytes caps how much of a page we hand back to the model.
+const maxFetchBytes = 4000
+
+// webFetch retrieves a public URL and returns a truncated snippet of its
+// body, so the model can ground answers in a page's actual content.
+type webFetch struct{}
+
+func (*webFetch) Name() string { return "web_fetch" }
+func (*webFetch) Description() string {
+	return "Загружает содержимое публичной веб-страницы по URL."
+}
+
+func (*webFetch) JSONSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"url": {"type": "string", "description": "Полный URL, включая http:// или https://"}
+		},
+		"required": ["url"]
+	}`)
+}
+
+func (*webFetch) Invoke(ctx context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("некорректные аргументы: %w", err)
+	}
+	if err := validateFetchURL(params.URL); err != nil {
+		return "", err
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, params.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := safeFetchClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ошибка загрузки: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("страница вернула %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBytes))
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения: %w", err)
+	}
+	return string(body), nil
+}
+
+// safeFetchClient is shared by every web_fetch call. Its transport dials
+// through dialValidated, which is the only place a hostname gets resolved —
+// so the address that's actually connected to is the one that was checked,
+// instead of validating one resolution and letting the transport perform a
+// second, independent one to dial (a classic DNS-rebinding TOCTOU).
+var safeFetchClient = &http.Client{
+	Transport: &http.Transport{DialContext: dialValidated},
+	// A redirect can point anywhere regardless of the original host; the
+	// address safety check happens again for it too, since dialValidated
+	// runs on every dial this client makes, redirects included.
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return fmt.Errorf("слишком много перенаправлений")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("поддерживаются только http(s) URL")
+		}
+		return nil
+	},
+}
+
+// dialValidated resolves addr's host exactly once, rejects it if the
+// resolved address is loopback, private, link-local, or unspecified, and
+// dials that specific IP — so nothing re-resolves the hostname afterwards.
+// The original hostname is still used for TLS SNI and the Host header,
+// since those come from the request URL, not from this dial address.
+func dialValidated(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := resolveSafeIP(host)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+}
+
+// validateFetchURL rejects anything that isn't a plain public http(s) URL
+// with a host; the host's actual address is validated once, at dial time,
+// by resolveSafeIP.
+func validateFetchURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("некорректный URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("поддерживаются только http(s) URL")
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("URL без хоста")
+	}
+	return nil
+}
+
+// resolveSafeIP resolves host — which may itself be an IP literal — and
+// returns the first resulting address that isn't loopback, private,
+// link-local, or unspecified. A hostname is only safe to fetch once its
+// actual resolved address is checked, since a model-supplied domain can
+// point at 127.0.0.1 or a cloud metadata address just as easily as an IP
+// literal can.
+func resolveSafeIP(host string) (net.IP, error) {
+	if strings.EqualFold(host, "localhost") {
+		return nil, fmt.Errorf("обращение к локальному хосту запрещено")
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		resolved, err := net.LookupIP(host)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разрешить хост: %w", err)
+		}
+		ips = resolved
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			continue
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("обращение к внутреннему адресу запрещено")
+}